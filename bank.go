@@ -6,9 +6,10 @@
 package bank
 
 import (
-	"encoding/gob"
+	"crypto/ed25519"
 	"fmt"
-	"os"
+	"math/big"
+	"sync"
 
 	"github.com/pkg/errors"
 )
@@ -18,34 +19,104 @@ import (
 // The fields should be unexported and accessed via Name(), Balance(),
 // and History() only. However, `gob` enconding and
 // decoding (used by Save and Load) requires struct fields to be exported.
-
+//
+// Bal is a *big.Int rather than a plain int so that balances denominated
+// in very small units (e.g. a wei-like currency) cannot silently overflow.
+// *big.Int already implements GobEncode/GobDecode, so Account remains
+// gob-compatible; Load additionally knows how to migrate a bank.data
+// file written by the old int-based Account (see migrate.go).
+//
+// PubKey and Sequence support the signed Tx model in tx.go: a nil
+// PubKey means the account only accepts the unsigned, trusted-mode
+// operations below (Deposit, Withdraw, Transfer); once a PubKey is set,
+// SignedDeposit/SignedWithdraw/SignedTransfer also become available and
+// Sequence tracks the last accepted Tx to reject replays.
+//
+// mu guards Bal, Hist, and Sequence. It is unexported, so gob simply
+// skips it on encode and leaves it at its zero value (unlocked) on
+// decode.
 type Account struct {
 	Name string
-	Bal  int
+	Bal  *big.Int
 	Hist []history
+
+	PubKey   ed25519.PublicKey
+	Sequence uint64
+
+	mu sync.Mutex
 }
 
 type history struct {
-	Amt, Bal int
+	Amt, Bal *big.Int
 }
 
-var accounts map[string]*Account
+// Bank owns a set of accounts and serializes access to them. A zero Bank
+// is not usable; create one with NewBank.
+type Bank struct {
+	mu       sync.RWMutex
+	accounts map[string]*Account
+	storage  Storage
+	journal  journal
+	wal      *WAL
+	saveMu   sync.Mutex
+}
+
+// NewBank creates an empty, ready-to-use Bank that persists through the
+// given Storage.
+func NewBank(storage Storage) *Bank {
+	return &Bank{accounts: make(map[string]*Account), storage: storage}
+}
+
+// UseWAL attaches a write-ahead log to the bank. Once attached, every
+// mutating operation durably records itself in wal before changing the
+// bank's in-memory state, so Load can replay wal on top of the last
+// snapshot to recover from a crash. Call UseWAL during setup, before
+// any goroutine starts using the bank concurrently.
+func (b *Bank) UseWAL(wal *WAL) {
+	b.mu.Lock()
+	b.wal = wal
+	b.mu.Unlock()
+}
+
+// defaultBank backs the package-level functions below, which exist only
+// for backward compatibility with code written before Bank was introduced.
+// It persists to bank.data in the original gob format.
+var defaultBank = NewBank(NewGobFileStorage("bank.data"))
 
 // NewAccount creates a new account with a name. Initial balance is 0.
 // The new account is added to the bank's map of accounts.
-func NewAccount(s string) *Account {
-	if accounts == nil {
-		accounts = make(map[string]*Account)
+func (b *Bank) NewAccount(s string) (*Account, error) {
+	if b.wal != nil {
+		if err := b.wal.append(walRecord{Op: walNewAccount, Name: s}); err != nil {
+			return nil, errors.Wrap(err, "NewAccount: WAL append failed")
+		}
 	}
-	a := &Account{Name: s}
-	accounts[s] = a
-	return a
+	a := &Account{Name: s, Bal: big.NewInt(0)}
+	b.mu.Lock()
+	b.accounts[s] = a
+	b.mu.Unlock()
+	b.journal.append(accountCreationEntry{name: s})
+	return a, nil
+}
+
+// AccountNames returns the name of every account currently known to the
+// bank, in no particular order. It's mainly useful for listing accounts,
+// e.g. from the server package.
+func (b *Bank) AccountNames() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	names := make([]string, 0, len(b.accounts))
+	for name := range b.accounts {
+		names = append(names, name)
+	}
+	return names
 }
 
 // GetAccount receives a name and returns the account of that name, if it exists.
-// GetAccount panics if the bank has no accounts.
-func GetAccount(name string) (*Account, error) {
-	accnt, ok := accounts[name]
+func (b *Bank) GetAccount(name string) (*Account, error) {
+	b.mu.RLock()
+	accnt, ok := b.accounts[name]
+	b.mu.RUnlock()
 	if !ok {
 		return nil, errors.New("account '" + name + "' does not exist")
 	}
@@ -54,65 +125,149 @@ func GetAccount(name string) (*Account, error) {
 
 // ListAccounts returns a formatted string that lists
 // each account and its current balance.
-func ListAccounts() string {
+func (b *Bank) ListAccounts() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	list := "Accounts:\n"
-	for _, v := range accounts {
-		list += fmt.Sprintf("Account: %s, balance: %d\n", v.Name, v.Bal)
+	for _, v := range b.accounts {
+		v.mu.Lock()
+		list += fmt.Sprintf("Account: %s, balance: %s\n", v.Name, v.Bal.String())
+		v.mu.Unlock()
 	}
 	return list
 }
 
-// Name returns the name of account a.
-func Name(a *Account) string {
-	return a.Name
+// Deposit adds amount m to account a's balance.
+// The amount must be positive.
+func (b *Bank) Deposit(a *Account, m *big.Int) (*big.Int, error) {
+	if m == nil {
+		return Balance(a), errors.New("Deposit: amount must not be nil")
+	}
+	if m.Sign() < 0 {
+		return Balance(a), errors.Errorf("Deposit: amount must be positive, but is %s.", m.String())
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return b.depositLocked(a, m)
 }
 
-// Balance returns the current balance of account a.
-func Balance(a *Account) int {
-	return a.Bal
+// depositLocked applies a validated deposit of m to a. The caller must
+// already hold a.mu and keep holding it until depositLocked returns --
+// it's split out of Deposit so verifyLocked (tx.go) can verify a signed
+// Tx and apply its mutation in the same critical section, instead of
+// releasing a.mu in between and letting another Tx interleave out of
+// Sequence order.
+func (b *Bank) depositLocked(a *Account, m *big.Int) (*big.Int, error) {
+	if b.wal != nil {
+		if err := b.wal.append(walRecord{Op: walDeposit, Name: a.Name, Amount: m}); err != nil {
+			return new(big.Int).Set(a.Bal), errors.Wrap(err, "Deposit: WAL append failed")
+		}
+	}
+	prevBal, prevHistLen := new(big.Int).Set(a.Bal), len(a.Hist)
+	a.Bal = new(big.Int).Add(a.Bal, m)
+	a.Hist = append(a.Hist, history{new(big.Int).Set(m), new(big.Int).Set(a.Bal)})
+	b.journal.append(balanceChangeEntry{name: a.Name, prevBal: prevBal, prevHistLen: prevHistLen})
+	return new(big.Int).Set(a.Bal), nil
 }
 
-// Deposit adds amount m to account a's balance.
-// The amount must be positive.
-func Deposit(a *Account, m int) (int, error) {
-	if m < 0 {
-		return a.Bal, errors.Errorf("Deposit: amount must be positive, but is %d.", m)
+// Withdraw removes amount m from account a's balance.
+// The amount must be positive, and it must not exceed the account's
+// current balance (Bal is unsigned in spirit: it must never go negative).
+func (b *Bank) Withdraw(a *Account, m *big.Int) (*big.Int, error) {
+	if m == nil {
+		return Balance(a), errors.New("Withdraw: amount must not be nil")
+	}
+	if m.Sign() < 0 {
+		return Balance(a), errors.Errorf("Withdraw: amount must be positive, but is %s.", m.String())
 	}
-	a.Bal += m
-	a.Hist = append(a.Hist, history{m, a.Bal})
-	return a.Bal, nil
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return b.withdrawLocked(a, m)
 }
 
-// Withdraw removes amount m from account a's balance.
-// The amount must be positive.
-func Withdraw(a *Account, m int) (int, error) {
-	if m < 0 {
-		return a.Bal, errors.Errorf("Withdraw: amount must be positive, but is %d.", m)
+// withdrawLocked applies a validated withdrawal of m from a. The
+// caller must already hold a.mu; see depositLocked for why.
+func (b *Bank) withdrawLocked(a *Account, m *big.Int) (*big.Int, error) {
+	if m.Cmp(a.Bal) > 0 {
+		return new(big.Int).Set(a.Bal), errors.Errorf("Withdraw: amount (%s) must be less than actual balance (%s).", m.String(), a.Bal.String())
 	}
-	if m > a.Bal {
-		return a.Bal, errors.Errorf("Withdraw: amount (%d) must be less than actual balance (%d).", m, a.Bal)
+	if b.wal != nil {
+		if err := b.wal.append(walRecord{Op: walWithdraw, Name: a.Name, Amount: m}); err != nil {
+			return new(big.Int).Set(a.Bal), errors.Wrap(err, "Withdraw: WAL append failed")
+		}
 	}
-	a.Bal -= m
-	a.Hist = append(a.Hist, history{-m, a.Bal})
-	return a.Bal, nil
+	prevBal, prevHistLen := new(big.Int).Set(a.Bal), len(a.Hist)
+	a.Bal = new(big.Int).Sub(a.Bal, m)
+	a.Hist = append(a.Hist, history{new(big.Int).Neg(m), new(big.Int).Set(a.Bal)})
+	b.journal.append(balanceChangeEntry{name: a.Name, prevBal: prevBal, prevHistLen: prevHistLen})
+	return new(big.Int).Set(a.Bal), nil
 }
 
 // Transfer transfers amount m from account a to account b.
 // The amount must be positive.
 // The sending account must have at least as much money as the
 // amount to be transferred.
-func Transfer(a, b *Account, m int) (int, int, error) {
-	switch {
-	case m < 0:
-		return a.Bal, b.Bal, errors.Errorf("Transfer: amount must be positive, but is %d.", m)
-	case m > a.Bal:
-		return 0, a.Bal, errors.Errorf("Withdraw: amount (%d) must be less than actual balance of sending account (%d).", m, a.Bal)
+func (b *Bank) Transfer(a, c *Account, m *big.Int) (*big.Int, *big.Int, error) {
+	if m == nil {
+		return Balance(a), Balance(c), errors.New("Transfer: amount must not be nil")
+	}
+	if m.Sign() < 0 {
+		return Balance(a), Balance(c), errors.Errorf("Transfer: amount must be positive, but is %s.", m.String())
+	}
+	unlock := lockPair(a, c)
+	defer unlock()
+	return b.transferLocked(a, c, m)
+}
+
+// transferLocked applies a validated transfer of m from a to c. The
+// caller must already hold both a.mu and c.mu, acquired via lockPair;
+// see depositLocked for why.
+func (b *Bank) transferLocked(a, c *Account, m *big.Int) (*big.Int, *big.Int, error) {
+	if m.Cmp(a.Bal) > 0 {
+		return new(big.Int).Set(a.Bal), new(big.Int).Set(c.Bal), errors.Errorf("Transfer: amount (%s) must be less than actual balance of sending account (%s).", m.String(), a.Bal.String())
+	}
+
+	// Both legs are written as a single walTransfer record so a crash
+	// between debiting the sender and crediting the receiver can't
+	// happen: replay either sees the whole transfer or none of it, never
+	// just the debit (see wal.go's replayWAL and walTransfer).
+	if b.wal != nil {
+		if err := b.wal.append(walRecord{Op: walTransfer, From: a.Name, To: c.Name, Amount: m}); err != nil {
+			return new(big.Int).Set(a.Bal), new(big.Int).Set(c.Bal), errors.Wrap(err, "Transfer: WAL append failed")
+		}
+	}
+
+	aPrevBal, aPrevHistLen := new(big.Int).Set(a.Bal), len(a.Hist)
+	a.Bal = new(big.Int).Sub(a.Bal, m)
+	a.Hist = append(a.Hist, history{new(big.Int).Neg(m), new(big.Int).Set(a.Bal)})
+	b.journal.append(balanceChangeEntry{name: a.Name, prevBal: aPrevBal, prevHistLen: aPrevHistLen})
+
+	cPrevBal, cPrevHistLen := new(big.Int).Set(c.Bal), len(c.Hist)
+	c.Bal = new(big.Int).Add(c.Bal, m)
+	c.Hist = append(c.Hist, history{new(big.Int).Set(m), new(big.Int).Set(c.Bal)})
+	b.journal.append(balanceChangeEntry{name: c.Name, prevBal: cPrevBal, prevHistLen: cPrevHistLen})
+
+	return new(big.Int).Set(a.Bal), new(big.Int).Set(c.Bal), nil
+}
+
+// lockPair locks a and c in a deterministic order (by name) so that
+// two concurrent calls locking the same pair, in either direction, can
+// never deadlock, and returns a func that unlocks them again.
+func lockPair(a, c *Account) func() {
+	first, second := a, c
+	if first.Name > second.Name {
+		first, second = second, first
+	}
+	first.mu.Lock()
+	if second != first {
+		second.mu.Lock()
+	}
+	return func() {
+		if second != first {
+			second.mu.Unlock()
+		}
+		first.mu.Unlock()
 	}
-	a.Bal -= m
-	b.Bal += m
-	a.Hist = append(a.Hist, history{-m, a.Bal})
-	b.Hist = append(b.Hist, history{m, b.Bal})
-	return a.Bal, b.Bal, nil
 }
 
 // History returns a closure that returns one account transaction at a time.
@@ -121,65 +276,140 @@ func Transfer(a, b *Account, m int) (int, int, error) {
 // The closure returns the history items from oldest to newest.
 // The closure panics if it is called again after its third return value has
 // turned "false".
-func History(a *Account) func() (amt, bal int, more bool) {
+func History(a *Account) func() (amt, bal *big.Int, more bool) {
+	a.mu.Lock()
+	hist := append([]history(nil), a.Hist...)
+	a.mu.Unlock()
+
 	i := 0
 	more := true
-	return func() (int, int, bool) {
-		if len(a.Hist) == 0 {
-			return 0, 0, false
+	return func() (*big.Int, *big.Int, bool) {
+		if len(hist) == 0 {
+			return nil, nil, false
 		}
-		if i >= len(a.Hist)-1 {
+		if i >= len(hist)-1 {
 			more = false
 		}
-		h := a.Hist[i]
+		h := hist[i]
 		i++
 		return h.Amt, h.Bal, more
 	}
 }
 
-// Save stores the accounts map on disk.
-func Save() (err error) {
-	f, err := os.OpenFile("bank.data", os.O_WRONLY, 0666) // Note: octal #
-	if err != nil {
-		f, err = os.Create("bank.data")
-		if err != nil {
-			return errors.Wrap(err, "Save: Create failed")
-		}
+// Name returns the name of account a.
+func Name(a *Account) string {
+	return a.Name
+}
+
+// Balance returns the current balance of account a.
+func Balance(a *Account) *big.Int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return new(big.Int).Set(a.Bal)
+}
+
+// Save persists the bank's accounts through its Storage backend.
+//
+// saveMu serializes the call into storage.Save: Storage implementations
+// only guard against concurrent mutation of a single snapshot, not
+// against two overlapping Save calls writing the same file out of
+// order, so Bank must keep them from overlapping itself.
+func (b *Bank) Save() error {
+	b.mu.RLock()
+	accounts := make(map[string]*Account, len(b.accounts))
+	for name, a := range b.accounts {
+		accounts[name] = a
 	}
-	defer func() {
-		e := f.Close()
-		if e != nil {
-			if err == nil {
-				err = e
-				return
-			}
-			err = errors.Wrap(err, e.Error())
-		}
-	}()
+	b.mu.RUnlock()
 
-	e := gob.NewEncoder(f)
-	err = e.Encode(accounts)
-	if err != nil {
-		return errors.Wrap(err, "Save: Encode failed")
+	b.saveMu.Lock()
+	defer b.saveMu.Unlock()
+	if err := b.storage.Save(accounts); err != nil {
+		return errors.Wrap(err, "Save failed")
 	}
 	return nil
 }
 
-// Load restores the accounts map from disk.
-func Load() error {
-	f, err := os.Open("bank.data")
+// Load restores the bank's accounts from its Storage backend and, if a
+// WAL is attached, replays it on top to recover any operations that
+// happened after the last snapshot.
+func (b *Bank) Load() error {
+	accounts, err := b.storage.Load()
 	if err != nil {
-		if os.IsNotExist(err) {
-			// Expected. The file does not exist initially.
-			return nil
+		return errors.Wrap(err, "Load failed")
+	}
+
+	if b.wal != nil {
+		if err := replayWAL(accounts, b.wal); err != nil {
+			return errors.Wrap(err, "Load: WAL replay failed")
 		}
-		return errors.Wrap(err, "Load: Open failed")
 	}
-	defer f.Close() // closing a readonly file needs no error checking
-	d := gob.NewDecoder(f)
-	err = d.Decode(&accounts)
-	if err != nil {
-		return errors.Wrap(err, "Load: Decode failed")
+
+	b.mu.Lock()
+	b.accounts = accounts
+	b.mu.Unlock()
+	return nil
+}
+
+// Compact folds the WAL into a fresh snapshot: it saves the bank's
+// current state through Storage, empties the WAL so the next Load
+// starts from that snapshot instead of replaying everything recorded so
+// far, and resets the in-memory undo journal, since every entry older
+// than this snapshot is now unreachable. Without this, a long-running
+// bank's journal would grow for as long as the process runs. Compact
+// should not be called while another goroutine's mutation is in
+// flight, the same constraint UseWAL documents for attaching a WAL.
+func (b *Bank) Compact() error {
+	if b.wal == nil {
+		return errors.New("Compact: no WAL attached")
+	}
+	if err := b.Save(); err != nil {
+		return errors.Wrap(err, "Compact: Save failed")
+	}
+	if err := b.wal.truncate(); err != nil {
+		return errors.Wrap(err, "Compact: truncate failed")
 	}
+	b.journal.reset()
 	return nil
 }
+
+// NewAccount creates a new account on the default bank. It exists for
+// callers that predate the introduction of Bank, so it keeps their
+// original single-return signature -- the error Bank.NewAccount can
+// now return only comes from an attached WAL, and defaultBank is never
+// exposed to UseWAL, so it's always nil here.
+func NewAccount(s string) *Account {
+	a, _ := defaultBank.NewAccount(s)
+	return a
+}
+
+// GetAccount looks up an account by name on the default bank. It exists
+// for callers that predate the introduction of Bank.
+func GetAccount(name string) (*Account, error) { return defaultBank.GetAccount(name) }
+
+// ListAccounts lists the default bank's accounts. It exists for callers
+// that predate the introduction of Bank.
+func ListAccounts() string { return defaultBank.ListAccounts() }
+
+// Deposit adds amount m to account a's balance on the default bank. It
+// exists for callers that predate the introduction of Bank.
+func Deposit(a *Account, m *big.Int) (*big.Int, error) { return defaultBank.Deposit(a, m) }
+
+// Withdraw removes amount m from account a's balance on the default
+// bank. It exists for callers that predate the introduction of Bank.
+func Withdraw(a *Account, m *big.Int) (*big.Int, error) { return defaultBank.Withdraw(a, m) }
+
+// Transfer transfers amount m from account a to account b on the
+// default bank. It exists for callers that predate the introduction of
+// Bank.
+func Transfer(a, b *Account, m *big.Int) (*big.Int, *big.Int, error) {
+	return defaultBank.Transfer(a, b, m)
+}
+
+// Save stores the default bank's accounts map on disk. It exists for
+// callers that predate the introduction of Bank.
+func Save() error { return defaultBank.Save() }
+
+// Load restores the default bank's accounts map from disk. It exists
+// for callers that predate the introduction of Bank.
+func Load() error { return defaultBank.Load() }
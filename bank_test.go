@@ -2,12 +2,21 @@ package bank
 
 import (
 	"fmt"
-	"reflect"
+	"math/big"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
 )
 
+func big64(n int64) *big.Int { return big.NewInt(n) }
+
+// resetDefaultBank points the package-level wrapper functions at a fresh,
+// empty Bank so tests don't interfere with each other.
+func resetDefaultBank() {
+	defaultBank = NewBank(NewGobFileStorage("bank.data"))
+}
+
 func TestNewAccount(t *testing.T) {
 	type args struct {
 		s string
@@ -17,20 +26,24 @@ func TestNewAccount(t *testing.T) {
 		args args
 		want *Account
 	}{
-		{"Griesemer", args{"Griesemer"}, &Account{Name: "Griesemer", Bal: 0, Hist: nil}},
-		{"Pike", args{"Pike"}, &Account{Name: "Pike", Bal: 0, Hist: nil}},
-		{"Thompson", args{"Thompson"}, &Account{Name: "Thompson", Bal: 0, Hist: nil}},
+		{"Griesemer", args{"Griesemer"}, &Account{Name: "Griesemer", Bal: big64(0), Hist: nil}},
+		{"Pike", args{"Pike"}, &Account{Name: "Pike", Bal: big64(0), Hist: nil}},
+		{"Thompson", args{"Thompson"}, &Account{Name: "Thompson", Bal: big64(0), Hist: nil}},
 	}
-	accounts = map[string]*Account{}
+	resetDefaultBank()
 	for i, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Ensure that the correct account is created
-			if got := NewAccount(tt.args.s); !reflect.DeepEqual(got, tt.want) {
+			got := NewAccount(tt.args.s)
+			if got.Name != tt.want.Name || got.Bal.Cmp(tt.want.Bal) != 0 || len(got.Hist) != len(tt.want.Hist) {
 				t.Errorf("NewAccount() = %v, want %v", got, tt.want)
 			}
-			// Ensure each account gets inserted into accounts
-			if i+1 != len(accounts) || !reflect.DeepEqual(accounts[tt.name], tt.want) {
-				t.Errorf("len(accounts) = %v, want %v\naccounts[\"%v\"] is not %v\n", len(accounts), i+1, tt.name, tt.want)
+			// Ensure each account gets inserted into the default bank
+			if i+1 != len(defaultBank.accounts) {
+				t.Errorf("len(defaultBank.accounts) = %v, want %v", len(defaultBank.accounts), i+1)
+			}
+			if stored := defaultBank.accounts[tt.name]; stored.Name != tt.want.Name || stored.Bal.Cmp(tt.want.Bal) != 0 {
+				t.Errorf("defaultBank.accounts[%q] = %v, want %v", tt.name, stored, tt.want)
 			}
 		})
 	}
@@ -47,7 +60,7 @@ func TestName(t *testing.T) {
 		a *Account
 	}
 
-	pike := &Account{"Pike", 100, nil}
+	pike := &Account{Name: "Pike", Bal: big64(100)}
 
 	tests := []struct {
 		name string
@@ -69,18 +82,18 @@ func TestBalance(t *testing.T) {
 	type args struct {
 		a *Account
 	}
-	pike := &Account{"Pike", 100, nil}
+	pike := &Account{Name: "Pike", Bal: big64(100)}
 
 	tests := []struct {
 		name string
 		args args
-		want int
+		want *big.Int
 	}{
-		{"Pike 100", args{pike}, 100},
+		{"Pike 100", args{pike}, big64(100)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := Balance(tt.args.a); got != tt.want {
+			if got := Balance(tt.args.a); got.Cmp(tt.want) != 0 {
 				t.Errorf("Balance() = %v, want %v", got, tt.want)
 			}
 		})
@@ -90,25 +103,24 @@ func TestBalance(t *testing.T) {
 func TestDeposit(t *testing.T) {
 	type args struct {
 		a *Account
-		m int
+		m *big.Int
 	}
 
-	griesemer := &Account{"Griesemer", 100, nil}
-	pike := &Account{"Pike", 0, nil}
-	thompson := &Account{"Thompson", 0, nil}
+	griesemer := &Account{Name: "Griesemer", Bal: big64(100)}
+	pike := &Account{Name: "Pike", Bal: big64(0)}
+	thompson := &Account{Name: "Thompson", Bal: big64(0)}
 
 	tests := []struct {
 		name    string
 		args    args
-		want    int
-		hist    []history
+		want    *big.Int
 		wantErr bool
 	}{
-		{"Griesemer deposits 100", args{griesemer, 100}, 200, []history{{100, 200}}, false},
-		{"Pike deposits 42", args{pike, 42}, 42, []history{{42, 42}}, false},
-		{"Pike deposits -1", args{pike, -1}, 42, []history{{42, 42}}, true},
-		{"Thompson deposits 60", args{thompson, 60}, 60, []history{{60, 60}}, false},
-		{"Thompson deposits 99", args{thompson, 39}, 99, []history{{60, 60}, {39, 99}}, false},
+		{"Griesemer deposits 100", args{griesemer, big64(100)}, big64(200), false},
+		{"Pike deposits 42", args{pike, big64(42)}, big64(42), false},
+		{"Pike deposits -1", args{pike, big64(-1)}, big64(42), true},
+		{"Thompson deposits 60", args{thompson, big64(60)}, big64(60), false},
+		{"Thompson deposits 99", args{thompson, big64(39)}, big64(99), false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -117,33 +129,44 @@ func TestDeposit(t *testing.T) {
 				t.Errorf("Deposit() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
+			if got.Cmp(tt.want) != 0 {
 				t.Errorf("Deposit() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestDepositNilAmount(t *testing.T) {
+	a := &Account{Name: "Griesemer", Bal: big64(100)}
+	got, err := Deposit(a, nil)
+	if err == nil {
+		t.Error("Deposit(a, nil) succeeded, want error")
+	}
+	if got.Cmp(big64(100)) != 0 {
+		t.Errorf("Deposit(a, nil) = %v, want balance unchanged at 100", got)
+	}
+}
+
 func TestWithdraw(t *testing.T) {
 	type args struct {
 		a *Account
-		m int
+		m *big.Int
 	}
 
-	griesemer := &Account{"Griesemer", 100, nil}
-	pike := &Account{"Pike", 100, nil}
-	thompson := &Account{"Thompson", 100, nil}
+	griesemer := &Account{Name: "Griesemer", Bal: big64(100)}
+	pike := &Account{Name: "Pike", Bal: big64(100)}
+	thompson := &Account{Name: "Thompson", Bal: big64(100)}
 
 	tests := []struct {
 		name    string
 		args    args
-		want    int
+		want    *big.Int
 		wantErr bool
 	}{
-		{"Griesemer withdraws 100", args{griesemer, 100}, 0, false},
-		{"Pike withdraws 42", args{pike, 42}, 58, false},
-		{"Pike withdraws -1", args{pike, -1}, 58, true},
-		{"Thompson withdraws 60", args{thompson, 101}, 100, true},
+		{"Griesemer withdraws 100", args{griesemer, big64(100)}, big64(0), false},
+		{"Pike withdraws 42", args{pike, big64(42)}, big64(58), false},
+		{"Pike withdraws -1", args{pike, big64(-1)}, big64(58), true},
+		{"Thompson withdraws 60", args{thompson, big64(101)}, big64(100), true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -152,34 +175,45 @@ func TestWithdraw(t *testing.T) {
 				t.Errorf("Withdraw() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
+			if got.Cmp(tt.want) != 0 {
 				t.Errorf("Withdraw() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestWithdrawNilAmount(t *testing.T) {
+	a := &Account{Name: "Griesemer", Bal: big64(100)}
+	got, err := Withdraw(a, nil)
+	if err == nil {
+		t.Error("Withdraw(a, nil) succeeded, want error")
+	}
+	if got.Cmp(big64(100)) != 0 {
+		t.Errorf("Withdraw(a, nil) = %v, want balance unchanged at 100", got)
+	}
+}
+
 func TestTransfer(t *testing.T) {
 	type args struct {
 		a *Account
 		b *Account
-		m int
+		m *big.Int
 	}
-	griesemer := &Account{"Griesemer", 100, nil}
-	pike := &Account{"Pike", 100, nil}
-	thompson := &Account{"Thompson", 100, nil}
+	griesemer := &Account{Name: "Griesemer", Bal: big64(100)}
+	pike := &Account{Name: "Pike", Bal: big64(100)}
+	thompson := &Account{Name: "Thompson", Bal: big64(100)}
 
 	tests := []struct {
 		name    string
 		args    args
-		want    int
-		want1   int
+		want    *big.Int
+		want1   *big.Int
 		wantErr bool
 	}{
-		{"Griesemer transfers 100 to Pike", args{griesemer, pike, 100}, 0, 200, false},
-		{"Griesemer transfers 100 to Pike again", args{griesemer, pike, 100}, 0, 200, true},
-		{"Pike transfers 300 to Thompson", args{pike, thompson, 300}, 200, 100, true},
-		{"Pike transfers -100 to Thompson", args{pike, thompson, -100}, 200, 100, true},
+		{"Griesemer transfers 100 to Pike", args{griesemer, pike, big64(100)}, big64(0), big64(200), false},
+		{"Griesemer transfers 100 to Pike again", args{griesemer, pike, big64(100)}, big64(0), big64(200), true},
+		{"Pike transfers 300 to Thompson", args{pike, thompson, big64(300)}, big64(200), big64(100), true},
+		{"Pike transfers -100 to Thompson", args{pike, thompson, big64(-100)}, big64(200), big64(100), true},
 	}
 
 	for _, tt := range tests {
@@ -189,44 +223,56 @@ func TestTransfer(t *testing.T) {
 				t.Errorf("Transfer() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !tt.wantErr && got != tt.want {
+			if !tt.wantErr && got.Cmp(tt.want) != 0 {
 				t.Errorf("Transfer() got = %v, want %v", got, tt.want)
 			}
-			if !tt.wantErr && got1 != tt.want1 {
+			if !tt.wantErr && got1.Cmp(tt.want1) != 0 {
 				t.Errorf("Transfer() got1 = %v, want %v", got1, tt.want1)
 			}
 		})
 	}
 }
 
+func TestTransferNilAmount(t *testing.T) {
+	a := &Account{Name: "Griesemer", Bal: big64(100)}
+	c := &Account{Name: "Pike", Bal: big64(0)}
+	got, got1, err := Transfer(a, c, nil)
+	if err == nil {
+		t.Error("Transfer(a, c, nil) succeeded, want error")
+	}
+	if got.Cmp(big64(100)) != 0 || got1.Cmp(big64(0)) != 0 {
+		t.Errorf("Transfer(a, c, nil) = %v, %v, want balances unchanged at 100, 0", got, got1)
+	}
+}
+
 func TestHistory(t *testing.T) {
 	type args struct {
-		a Account
+		a *Account
 	}
 
-	pike := Account{"Pike", 100, nil}
+	pike := &Account{Name: "Pike", Bal: big64(100)}
 	pike.Hist = []history{
-		{100, 100},
-		{10, 110},
-		{-40, 70},
-		{23, 93},
+		{big64(100), big64(100)},
+		{big64(10), big64(110)},
+		{big64(-40), big64(70)},
+		{big64(23), big64(93)},
 	}
 
 	tests := []struct {
 		name     string
 		args     args
-		wantAmt  []int
-		wantBal  []int
+		wantAmt  []int64
+		wantBal  []int64
 		wantMore []bool
 	}{
-		{"Pike's account history", args{pike}, []int{100, 10, -40, 23}, []int{100, 110, 70, 93}, []bool{true, true, true, false}},
+		{"Pike's account history", args{pike}, []int64{100, 10, -40, 23}, []int64{100, 110, 70, 93}, []bool{true, true, true, false}},
 	}
 	for _, tt := range tests {
-		h := History(&pike)
+		h := History(pike)
 		t.Run(tt.name, func(t *testing.T) {
 			for i := 0; i < len(pike.Hist); i++ {
 				amt, bal, more := h()
-				if amt != tt.wantAmt[i] || bal != tt.wantBal[i] || more != tt.wantMore[i] {
+				if amt.Int64() != tt.wantAmt[i] || bal.Int64() != tt.wantBal[i] || more != tt.wantMore[i] {
 					t.Errorf("History() = %v, %v, %v, want %v, %v, %v", amt, bal, more, tt.wantAmt[i], tt.wantBal[i], tt.wantMore[i])
 				}
 			}
@@ -243,23 +289,75 @@ func TestSaveAndLoad(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_ = NewAccount("Hiasl")
+			NewAccount("Hiasl")
 			if err := Save(); err != nil {
 				t.Errorf("Save() error = %v, stack = %v", err, errors.WithStack(err))
 			}
 		})
 		t.Run(tt.name, func(t *testing.T) {
-			accounts = nil
 			if err := Load(); err != nil {
 				t.Errorf("Load() error = %v, stack = %v", err, errors.WithStack(err))
 			}
-			if accounts == nil {
-				t.Errorf("accounts not restored: %v", accounts)
+			hiasl, err := GetAccount("Hiasl")
+			if err != nil {
+				t.Errorf("GetAccount(%q) error = %v", "Hiasl", err)
 			}
-			hiasl, ok := accounts["Hiasl"]
-			if !ok {
-				t.Errorf("accounts = %v, hiasl = %v", accounts, hiasl)
+			if hiasl == nil {
+				t.Errorf("accounts not restored")
 			}
 		})
 	}
 }
+
+// TestConcurrentTransfers hammers a handful of accounts from many
+// goroutines at once and checks that the sum of all balances is
+// conserved, i.e. that Deposit/Withdraw/Transfer never race.
+func TestConcurrentTransfers(t *testing.T) {
+	const (
+		numAccounts   = 8
+		numGoroutines = 50
+		numRounds     = 200
+		startBal      = 1000
+	)
+
+	b := NewBank(NewGobFileStorage("bank.data"))
+	accs := make([]*Account, numAccounts)
+	for i := range accs {
+		a, err := b.NewAccount(fmt.Sprintf("acct-%d", i))
+		if err != nil {
+			t.Fatalf("NewAccount() error = %v", err)
+		}
+		accs[i] = a
+		if _, err := b.Deposit(accs[i], big64(startBal)); err != nil {
+			t.Fatalf("Deposit() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func(seed int) {
+			defer wg.Done()
+			for r := 0; r < numRounds; r++ {
+				from := accs[(seed+r)%numAccounts]
+				to := accs[(seed+r+1)%numAccounts]
+				if from == to {
+					continue
+				}
+				// Ignore insufficient-funds errors: they are expected
+				// under concurrent load and don't affect conservation.
+				_, _, _ = b.Transfer(from, to, big64(1))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := big.NewInt(0)
+	for _, a := range accs {
+		total.Add(total, Balance(a))
+	}
+	want := big64(numAccounts * startBal)
+	if total.Cmp(want) != 0 {
+		t.Errorf("total balance = %s, want %s (money was created or destroyed)", total, want)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"bytes"
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var accountsBucket = []byte("accounts")
+
+// BoltStorage persists each account as its own record in an embedded
+// BoltDB file, so saving one account doesn't rewrite the state of
+// every other account the way GobFileStorage and JSONFileStorage do.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+// NewBoltStorage opens (creating if necessary) a BoltDB file at path.
+func NewBoltStorage(path string) (*BoltStorage, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewBoltStorage: Open failed")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(accountsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "NewBoltStorage: CreateBucketIfNotExists failed")
+	}
+	return &BoltStorage{db: db}, nil
+}
+
+// Close releases the underlying BoltDB file.
+func (s *BoltStorage) Close() error {
+	return s.db.Close()
+}
+
+// Save writes each account as its own key/value record, replacing
+// whatever was stored before: records for names no longer present in
+// accounts are deleted, matching the Storage interface's contract.
+func (s *BoltStorage) Save(accounts map[string]*Account) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket)
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if _, ok := accounts[string(k)]; !ok {
+				if err := c.Delete(); err != nil {
+					return errors.Wrapf(err, "BoltStorage.Save: Delete %q failed", k)
+				}
+			}
+		}
+		for name, a := range accounts {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(a); err != nil {
+				return errors.Wrapf(err, "BoltStorage.Save: Encode %q failed", name)
+			}
+			if err := b.Put([]byte(name), buf.Bytes()); err != nil {
+				return errors.Wrapf(err, "BoltStorage.Save: Put %q failed", name)
+			}
+		}
+		return nil
+	})
+}
+
+// Load reads every account record back into a map.
+func (s *BoltStorage) Load() (map[string]*Account, error) {
+	accounts := map[string]*Account{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket)
+		return b.ForEach(func(k, v []byte) error {
+			a := &Account{}
+			if err := gob.NewDecoder(bytes.NewReader(v)).Decode(a); err != nil {
+				return errors.Wrapf(err, "BoltStorage.Load: Decode %q failed", k)
+			}
+			accounts[string(k)] = a
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "BoltStorage.Load: View failed")
+	}
+	return accounts, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"math/big"
+	"sync"
+)
+
+// journalEntry is a single entry in a Bank's in-memory undo journal. It
+// mirrors the state-journal pattern used by go-ethereum: every entry
+// knows how to revert itself and which account it touched. revert locks
+// whatever account it mutates itself, so callers of RevertToSnapshot
+// don't need to hold any locks of their own.
+type journalEntry interface {
+	// revert undoes the effect of this entry on b. The caller must hold
+	// b.mu so b.accounts itself can't change concurrently.
+	revert(b *Bank)
+	// dirtied returns the name of the account this entry modified.
+	dirtied() string
+}
+
+// accountCreationEntry reverts the creation of a new account.
+type accountCreationEntry struct {
+	name string
+}
+
+func (e accountCreationEntry) revert(b *Bank)  { delete(b.accounts, e.name) }
+func (e accountCreationEntry) dirtied() string { return e.name }
+
+// balanceChangeEntry reverts a balance/history mutation on an existing
+// account back to what it was before the mutation.
+type balanceChangeEntry struct {
+	name        string
+	prevBal     *big.Int
+	prevHistLen int
+}
+
+func (e balanceChangeEntry) revert(b *Bank) {
+	a := b.accounts[e.name]
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Bal = e.prevBal
+	a.Hist = a.Hist[:e.prevHistLen]
+}
+func (e balanceChangeEntry) dirtied() string { return e.name }
+
+// journal is the ordered list of journalEntry values recorded since the
+// bank was created, or since it was last reset. Bank.RevertToSnapshot
+// uses it to undo operations on demand; Bank.Compact resets it once its
+// entries are no longer reachable, so a long-running bank doesn't grow
+// it without bound.
+type journal struct {
+	mu      sync.Mutex
+	entries []journalEntry
+}
+
+func (j *journal) append(e journalEntry) {
+	j.mu.Lock()
+	j.entries = append(j.entries, e)
+	j.mu.Unlock()
+}
+
+// length returns the current snapshot id, i.e. the number of entries
+// recorded so far.
+func (j *journal) length() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.entries)
+}
+
+// revertTo undoes every entry recorded since snapshot, in reverse order,
+// and discards them.
+//
+// It takes a copy of the entries to revert and truncates j.entries
+// before calling any entry's revert, releasing j.mu first. Entries lock
+// the account they touch (see balanceChangeEntry.revert), and
+// Deposit/Withdraw/Transfer lock their account before appending to the
+// journal; holding j.mu across revert as well would invert that lock
+// order and deadlock against those callers.
+func (j *journal) revertTo(b *Bank, snapshot int) {
+	j.mu.Lock()
+	reverted := append([]journalEntry(nil), j.entries[snapshot:]...)
+	j.entries = j.entries[:snapshot]
+	j.mu.Unlock()
+
+	for i := len(reverted) - 1; i >= 0; i-- {
+		reverted[i].revert(b)
+	}
+}
+
+// reset discards every entry, e.g. once Compact has made them
+// unreachable by taking a fresh durable snapshot.
+func (j *journal) reset() {
+	j.mu.Lock()
+	j.entries = nil
+	j.mu.Unlock()
+}
+
+// Snapshot returns an identifier for the bank's current state. Pass it
+// to RevertToSnapshot to undo every change made since.
+func (b *Bank) Snapshot() int {
+	return b.journal.length()
+}
+
+// RevertToSnapshot undoes every change made since the given snapshot id.
+// Each reverted entry locks whatever account it touches itself, so this
+// doesn't need the caller to hold any account locks. It's safe to call
+// concurrently with other Bank operations in the sense that it won't
+// deadlock or corrupt Bank state, but id stops being a meaningful
+// target the moment another goroutine reverts past it (e.g. past the
+// creation of an account id's changes apply to): callers that rely on a
+// snapshot surviving until they revert it must serialize among
+// themselves.
+func (b *Bank) RevertToSnapshot(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journal.revertTo(b, id)
+}
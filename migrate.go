@@ -0,0 +1,69 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+)
+
+// legacyAccount and legacyHistory mirror the pre-big.Int Account and
+// history types, as they were encoded into bank.data files before Bal
+// became a *big.Int. They exist solely so Load can migrate those old
+// files on the fly.
+type legacyAccount struct {
+	Name string
+	Bal  int
+	Hist []legacyHistory
+}
+
+type legacyHistory struct {
+	Amt, Bal int
+}
+
+// decodeAccounts decodes the accounts map from r, trying the current
+// gob format first and falling back to the legacy int-balance format
+// used before Account.Bal became a *big.Int.
+func decodeAccounts(r io.Reader) (map[string]*Account, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "decodeAccounts: read failed")
+	}
+
+	accounts := map[string]*Account{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&accounts); err == nil {
+		return accounts, nil
+	}
+
+	legacy := map[string]*legacyAccount{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&legacy); err != nil {
+		return nil, errors.Wrap(err, "decodeAccounts: not a current or legacy bank.data file")
+	}
+	return migrateLegacyAccounts(legacy), nil
+}
+
+// migrateLegacyAccounts converts accounts decoded from a pre-big.Int
+// bank.data file into the current Account representation.
+func migrateLegacyAccounts(legacy map[string]*legacyAccount) map[string]*Account {
+	accounts := make(map[string]*Account, len(legacy))
+	for name, la := range legacy {
+		hist := make([]history, len(la.Hist))
+		for i, lh := range la.Hist {
+			hist[i] = history{Amt: big.NewInt(int64(lh.Amt)), Bal: big.NewInt(int64(lh.Bal))}
+		}
+		accounts[name] = &Account{
+			Name: la.Name,
+			Bal:  big.NewInt(int64(la.Bal)),
+			Hist: hist,
+		}
+	}
+	return accounts
+}
@@ -0,0 +1,63 @@
+package bank
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLegacyAccounts(t *testing.T) {
+	legacy := map[string]*legacyAccount{
+		"Pike": {
+			Name: "Pike",
+			Bal:  60,
+			Hist: []legacyHistory{{Amt: 100, Bal: 100}, {Amt: -40, Bal: 60}},
+		},
+	}
+
+	got := migrateLegacyAccounts(legacy)
+	if len(got) != 1 {
+		t.Fatalf("len(migrateLegacyAccounts()) = %v, want 1", len(got))
+	}
+	pike, ok := got["Pike"]
+	if !ok {
+		t.Fatalf("migrateLegacyAccounts() missing %q", "Pike")
+	}
+	if pike.Bal.Cmp(big64(60)) != 0 {
+		t.Errorf("Pike.Bal = %v, want 60", pike.Bal)
+	}
+	if len(pike.Hist) != 2 || pike.Hist[0].Amt.Cmp(big64(100)) != 0 || pike.Hist[1].Bal.Cmp(big64(60)) != 0 {
+		t.Errorf("Pike.Hist = %+v, want [{100 100} {-40 60}]", pike.Hist)
+	}
+}
+
+// TestGobFileStorageLoadLegacyFormat checks that GobFileStorage.Load
+// transparently migrates a bank.data file written in the pre-big.Int,
+// int-balance format, as produced by an old build of this package.
+func TestGobFileStorageLoadLegacyFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.data")
+
+	legacy := map[string]*legacyAccount{
+		"Pike": {Name: "Pike", Bal: 60, Hist: []legacyHistory{{Amt: 100, Bal: 100}, {Amt: -40, Bal: 60}}},
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := gob.NewEncoder(f).Encode(legacy); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	s := NewGobFileStorage(path)
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 1 || got["Pike"].Bal.Cmp(big64(60)) != 0 {
+		t.Errorf("Load() = %v, want Pike with balance 60", got)
+	}
+}
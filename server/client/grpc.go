@@ -0,0 +1,110 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"bank/server/pb"
+)
+
+// grpcClient talks to a bank server's gRPC service.
+type grpcClient struct {
+	conn *grpc.ClientConn
+	c    pb.BankClient
+}
+
+func newGRPCClient(addr string) (*grpcClient, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcClient{conn: conn, c: pb.NewBankClient(conn)}, nil
+}
+
+// Close releases the underlying gRPC connection.
+func (c *grpcClient) Close() error {
+	return c.conn.Close()
+}
+
+func (c *grpcClient) newAccount(name string) (string, error) {
+	a, err := c.c.NewAccount(context.Background(), &pb.NewAccountRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+	return marshal(a)
+}
+
+func (c *grpcClient) getAccount(name string) (string, error) {
+	a, err := c.c.GetAccount(context.Background(), &pb.GetAccountRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+	return marshal(a)
+}
+
+func (c *grpcClient) listAccounts() (string, error) {
+	resp, err := c.c.ListAccounts(context.Background(), &pb.ListAccountsRequest{})
+	if err != nil {
+		return "", err
+	}
+	return marshal(resp.Accounts)
+}
+
+func (c *grpcClient) deposit(name, amount string) (string, error) {
+	a, err := c.c.Deposit(context.Background(), &pb.AmountRequest{Name: name, Amount: amount})
+	if err != nil {
+		return "", err
+	}
+	return marshal(a)
+}
+
+func (c *grpcClient) withdraw(name, amount string) (string, error) {
+	a, err := c.c.Withdraw(context.Background(), &pb.AmountRequest{Name: name, Amount: amount})
+	if err != nil {
+		return "", err
+	}
+	return marshal(a)
+}
+
+func (c *grpcClient) transfer(from, to, amount string) (string, error) {
+	resp, err := c.c.Transfer(context.Background(), &pb.TransferRequest{From: from, To: to, Amount: amount})
+	if err != nil {
+		return "", err
+	}
+	return marshal(resp)
+}
+
+func (c *grpcClient) history(name string) (string, error) {
+	stream, err := c.c.History(context.Background(), &pb.HistoryRequest{Name: name})
+	if err != nil {
+		return "", err
+	}
+	var entries []*pb.HistoryEntry
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		entries = append(entries, e)
+	}
+	return marshal(entries)
+}
+
+func marshal(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
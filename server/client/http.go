@@ -0,0 +1,91 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// httpClient talks to a bank server's REST/JSON API.
+type httpClient struct {
+	addr string
+}
+
+func newHTTPClient(addr string) *httpClient {
+	return &httpClient{addr: addr}
+}
+
+func (c *httpClient) newAccount(name string) (string, error) {
+	return c.post("/accounts", map[string]interface{}{"name": name})
+}
+
+func (c *httpClient) getAccount(name string) (string, error) {
+	return c.get("/accounts/" + name)
+}
+
+func (c *httpClient) listAccounts() (string, error) {
+	return c.get("/accounts")
+}
+
+func (c *httpClient) deposit(name, amount string) (string, error) {
+	return c.post("/accounts/"+name+"/deposit", map[string]interface{}{"amount": rawAmount(amount)})
+}
+
+func (c *httpClient) withdraw(name, amount string) (string, error) {
+	return c.post("/accounts/"+name+"/withdraw", map[string]interface{}{"amount": rawAmount(amount)})
+}
+
+func (c *httpClient) transfer(from, to, amount string) (string, error) {
+	return c.post("/transfer", map[string]interface{}{"from": from, "to": to, "amount": rawAmount(amount)})
+}
+
+func (c *httpClient) history(name string) (string, error) {
+	return c.get("/accounts/" + name + "/history")
+}
+
+// rawAmount passes an amount through to the request body as a bare
+// JSON number rather than a quoted string, since *big.Int's
+// UnmarshalJSON expects the former. amountArg has already validated
+// that amount is non-empty and numeric.
+func rawAmount(amount string) json.RawMessage {
+	return json.RawMessage(amount)
+}
+
+func (c *httpClient) get(path string) (string, error) {
+	resp, err := http.Get(c.addr + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return body(resp)
+}
+
+func (c *httpClient) post(path string, reqBody map[string]interface{}) (string, error) {
+	data, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Post(c.addr+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	return body(resp)
+}
+
+func body(resp *http.Response) (string, error) {
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("%s: %s", resp.Status, data)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command bankclient is a small CLI for talking to a running bank
+// server, over either its REST/JSON HTTP API (http.go) or its gRPC
+// service (grpc.go).
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// usageError marks an error as the user's fault (bad command, bad
+// argument) rather than a transport/server failure, so main can exit
+// 2 for it instead of 1, matching the original no-args/unknown-command
+// behavior.
+type usageError struct{ error }
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// bankClient is implemented by httpClient and grpcClient, one per
+// transport the server exposes.
+type bankClient interface {
+	newAccount(name string) (string, error)
+	getAccount(name string) (string, error)
+	listAccounts() (string, error)
+	deposit(name, amount string) (string, error)
+	withdraw(name, amount string) (string, error)
+	transfer(from, to, amount string) (string, error)
+	history(name string) (string, error)
+}
+
+func main() {
+	addr := flag.String("addr", "http://localhost:8080", "bank server address (host:port when -transport=grpc)")
+	transport := flag.String("transport", "http", "transport to use: http or grpc")
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+	}
+
+	c, err := newClient(*transport, *addr)
+	if err != nil {
+		fail(err)
+	}
+	if closer, ok := c.(interface{ Close() error }); ok {
+		defer closer.Close()
+	}
+
+	out, err := run(c, args)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Println(out)
+}
+
+// fail prints err and exits 2 if it's a usageError (bad invocation),
+// or 1 for any other (transport/server) failure.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	var uerr usageError
+	if errors.As(err, &uerr) {
+		os.Exit(2)
+	}
+	os.Exit(1)
+}
+
+func newClient(transport, addr string) (bankClient, error) {
+	switch transport {
+	case "http":
+		return newHTTPClient(addr), nil
+	case "grpc":
+		return newGRPCClient(strings.TrimPrefix(strings.TrimPrefix(addr, "https://"), "http://"))
+	default:
+		return nil, usageErrorf("unknown transport %q, want http or grpc", transport)
+	}
+}
+
+func run(c bankClient, args []string) (string, error) {
+	switch args[0] {
+	case "new":
+		return c.newAccount(arg(args, 1))
+	case "list":
+		return c.listAccounts()
+	case "get":
+		return c.getAccount(arg(args, 1))
+	case "deposit":
+		amount, err := amountArg(args, 2)
+		if err != nil {
+			return "", err
+		}
+		return c.deposit(arg(args, 1), amount)
+	case "withdraw":
+		amount, err := amountArg(args, 2)
+		if err != nil {
+			return "", err
+		}
+		return c.withdraw(arg(args, 1), amount)
+	case "transfer":
+		amount, err := amountArg(args, 3)
+		if err != nil {
+			return "", err
+		}
+		return c.transfer(arg(args, 1), arg(args, 2), amount)
+	case "history":
+		return c.history(arg(args, 1))
+	default:
+		return "", usageErrorf("unknown command %q", args[0])
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: bankclient [-addr ADDR] [-transport http|grpc] <new|list|get|deposit|withdraw|transfer|history> ...")
+	os.Exit(2)
+}
+
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// amountArg returns args[i] as a validated decimal amount, or an error
+// if it's missing or not a valid integer. Catching that here gives a
+// normal usage error instead of, e.g., json.Marshal failing deep in
+// the request path on an empty amount.
+func amountArg(args []string, i int) (string, error) {
+	s := arg(args, i)
+	if s == "" {
+		return "", usageErrorf("missing amount argument")
+	}
+	if _, ok := new(big.Int).SetString(s, 10); !ok {
+		return "", usageErrorf("invalid amount %q", s)
+	}
+	return s, nil
+}
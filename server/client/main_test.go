@@ -0,0 +1,208 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// fakeClient is a bankClient that records the call it received and
+// returns canned results, so run()'s dispatch can be tested without a
+// live server.
+type fakeClient struct {
+	calls []string
+	out   string
+	err   error
+}
+
+func (f *fakeClient) newAccount(name string) (string, error) {
+	f.calls = append(f.calls, "new "+name)
+	return f.out, f.err
+}
+
+func (f *fakeClient) getAccount(name string) (string, error) {
+	f.calls = append(f.calls, "get "+name)
+	return f.out, f.err
+}
+
+func (f *fakeClient) listAccounts() (string, error) {
+	f.calls = append(f.calls, "list")
+	return f.out, f.err
+}
+
+func (f *fakeClient) deposit(name, amount string) (string, error) {
+	f.calls = append(f.calls, "deposit "+name+" "+amount)
+	return f.out, f.err
+}
+
+func (f *fakeClient) withdraw(name, amount string) (string, error) {
+	f.calls = append(f.calls, "withdraw "+name+" "+amount)
+	return f.out, f.err
+}
+
+func (f *fakeClient) transfer(from, to, amount string) (string, error) {
+	f.calls = append(f.calls, "transfer "+from+" "+to+" "+amount)
+	return f.out, f.err
+}
+
+func (f *fakeClient) history(name string) (string, error) {
+	f.calls = append(f.calls, "history "+name)
+	return f.out, f.err
+}
+
+func TestRunDispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"new", []string{"new", "Pike"}, "new Pike", false},
+		{"list", []string{"list"}, "list", false},
+		{"get", []string{"get", "Pike"}, "get Pike", false},
+		{"deposit", []string{"deposit", "Pike", "100"}, "deposit Pike 100", false},
+		{"withdraw", []string{"withdraw", "Pike", "100"}, "withdraw Pike 100", false},
+		{"transfer", []string{"transfer", "Pike", "Thompson", "100"}, "transfer Pike Thompson 100", false},
+		{"history", []string{"history", "Pike"}, "history Pike", false},
+		{"deposit missing amount", []string{"deposit", "Pike"}, "", true},
+		{"withdraw invalid amount", []string{"withdraw", "Pike", "NaN"}, "", true},
+		{"transfer missing amount", []string{"transfer", "Pike", "Thompson"}, "", true},
+		{"unknown command", []string{"frobnicate"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeClient{out: "ok"}
+			_, err := run(c, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var uerr usageError
+				if !errors.As(err, &uerr) {
+					t.Errorf("run() error = %v, want a usageError", err)
+				}
+				return
+			}
+			if len(c.calls) != 1 || c.calls[0] != tt.want {
+				t.Errorf("calls = %v, want [%q]", c.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunPropagatesClientError(t *testing.T) {
+	c := &fakeClient{err: errors.New("server unavailable")}
+	_, err := run(c, []string{"list"})
+	if err == nil {
+		t.Fatal("run() succeeded, want error")
+	}
+	var uerr usageError
+	if errors.As(err, &uerr) {
+		t.Errorf("run() error = %v, want a non-usage error", err)
+	}
+}
+
+func TestAmountArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		i       int
+		want    string
+		wantErr bool
+	}{
+		{"valid", []string{"deposit", "Pike", "100"}, 2, "100", false},
+		{"negative", []string{"deposit", "Pike", "-100"}, 2, "-100", false},
+		{"missing", []string{"deposit", "Pike"}, 2, "", true},
+		{"not a number", []string{"deposit", "Pike", "abc"}, 2, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := amountArg(tt.args, tt.i)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("amountArg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var uerr usageError
+				if !errors.As(err, &uerr) {
+					t.Errorf("amountArg() error = %v, want a usageError", err)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("amountArg() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	if c, err := newClient("http", "http://localhost:8080"); err != nil {
+		t.Errorf("newClient(\"http\", ...) error = %v", err)
+	} else if _, ok := c.(*httpClient); !ok {
+		t.Errorf("newClient(\"http\", ...) = %T, want *httpClient", c)
+	}
+
+	// grpc.Dial is lazy: it returns immediately without contacting
+	// addr, so this doesn't need a live server either.
+	if c, err := newClient("grpc", "127.0.0.1:0"); err != nil {
+		t.Errorf("newClient(\"grpc\", ...) error = %v", err)
+	} else {
+		gc, ok := c.(*grpcClient)
+		if !ok {
+			t.Fatalf("newClient(\"grpc\", ...) = %T, want *grpcClient", c)
+		}
+		gc.Close()
+	}
+
+	if _, err := newClient("carrier-pigeon", "anywhere"); err == nil {
+		t.Error("newClient() with an unknown transport succeeded, want error")
+	} else {
+		var uerr usageError
+		if !errors.As(err, &uerr) {
+			t.Errorf("newClient() error = %v, want a usageError", err)
+		}
+	}
+}
+
+// TestFail exercises fail()'s exit code selection. fail calls os.Exit,
+// so it has to run in a subprocess: the test re-execs itself with an
+// env var that tells the child to call fail and nothing else, then
+// checks the child's exit code.
+func TestFail(t *testing.T) {
+	if os.Getenv("BANKCLIENT_TEST_FAIL") != "" {
+		if os.Getenv("BANKCLIENT_TEST_FAIL") == "usage" {
+			fail(usageErrorf("bad invocation"))
+		} else {
+			fail(errors.New("transport failure"))
+		}
+		return
+	}
+
+	tests := []struct {
+		name     string
+		kind     string
+		wantCode int
+	}{
+		{"usage error exits 2", "usage", 2},
+		{"other error exits 1", "other", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=TestFail")
+			cmd.Env = append(os.Environ(), "BANKCLIENT_TEST_FAIL="+tt.kind)
+			err := cmd.Run()
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("subprocess error = %v, want *exec.ExitError", err)
+			}
+			if got := exitErr.ExitCode(); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
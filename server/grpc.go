@@ -0,0 +1,138 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"bank"
+	"bank/server/pb"
+)
+
+// grpcServer adapts Server to pb.BankServer.
+type grpcServer struct {
+	*Server
+}
+
+// GRPCServer returns a pb.BankServer backed by s, ready to be passed to
+// pb.RegisterBankServer.
+func (s *Server) GRPCServer() pb.BankServer {
+	return &grpcServer{s}
+}
+
+func toPBAccount(a *bank.Account) *pb.Account {
+	return &pb.Account{Name: bank.Name(a), Balance: bank.Balance(a).String()}
+}
+
+func parseAmount(s string) (*big.Int, error) {
+	m, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid amount %q", s)
+	}
+	return m, nil
+}
+
+func (s *grpcServer) NewAccount(ctx context.Context, req *pb.NewAccountRequest) (*pb.Account, error) {
+	a, err := s.Bank.NewAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toPBAccount(a), nil
+}
+
+func (s *grpcServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	a, err := s.Bank.GetAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return toPBAccount(a), nil
+}
+
+func (s *grpcServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	names := s.Bank.AccountNames()
+	resp := &pb.ListAccountsResponse{}
+	for _, name := range names {
+		a, err := s.Bank.GetAccount(name)
+		if err != nil {
+			continue
+		}
+		resp.Accounts = append(resp.Accounts, toPBAccount(a))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) Deposit(ctx context.Context, req *pb.AmountRequest) (*pb.Account, error) {
+	a, err := s.Bank.GetAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Bank.Deposit(a, amount); err != nil {
+		return nil, err
+	}
+	return toPBAccount(a), nil
+}
+
+func (s *grpcServer) Withdraw(ctx context.Context, req *pb.AmountRequest) (*pb.Account, error) {
+	a, err := s.Bank.GetAccount(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Bank.Withdraw(a, amount); err != nil {
+		return nil, err
+	}
+	return toPBAccount(a), nil
+}
+
+func (s *grpcServer) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	from, err := s.Bank.GetAccount(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.Bank.GetAccount(req.To)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.Bank.Transfer(from, to, amount); err != nil {
+		return nil, err
+	}
+	return &pb.TransferResponse{From: toPBAccount(from), To: toPBAccount(to)}, nil
+}
+
+// History streams an account's history one entry at a time, mirroring
+// the closure-based bank.History iterator instead of materializing it.
+func (s *grpcServer) History(req *pb.HistoryRequest, stream pb.Bank_HistoryServer) error {
+	a, err := s.Bank.GetAccount(req.Name)
+	if err != nil {
+		return err
+	}
+	next := bank.History(a)
+	for {
+		amt, bal, more := next()
+		if amt == nil {
+			return nil
+		}
+		if err := stream.Send(&pb.HistoryEntry{Amount: amt.String(), Balance: bal.String()}); err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"bank"
+	"bank/server/pb"
+)
+
+// dialGRPC starts s's gRPC service on an ephemeral port and returns a
+// client dialed against it, closing both when the test ends.
+func dialGRPC(t *testing.T, s *Server) pb.BankClient {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterBankServer(srv, s.GRPCServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewBankClient(conn)
+}
+
+func TestGRPCConcurrentTransfers(t *testing.T) {
+	b := bank.NewBank(bank.NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+	s := New(b)
+	c := dialGRPC(t, s)
+	ctx := context.Background()
+
+	const (
+		numAccounts   = 6
+		numGoroutines = 20
+		numRounds     = 50
+		startBal      = "1000"
+	)
+
+	names := make([]string, numAccounts)
+	for i := range names {
+		names[i] = fmt.Sprintf("acct-%d", i)
+		if _, err := c.NewAccount(ctx, &pb.NewAccountRequest{Name: names[i]}); err != nil {
+			t.Fatalf("NewAccount() error = %v", err)
+		}
+		if _, err := c.Deposit(ctx, &pb.AmountRequest{Name: names[i], Amount: startBal}); err != nil {
+			t.Fatalf("Deposit() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func(seed int) {
+			defer wg.Done()
+			for r := 0; r < numRounds; r++ {
+				from := names[(seed+r)%numAccounts]
+				to := names[(seed+r+1)%numAccounts]
+				if from == to {
+					continue
+				}
+				// Ignore insufficient-funds errors: expected under
+				// concurrent load and don't affect conservation.
+				c.Transfer(ctx, &pb.TransferRequest{From: from, To: to, Amount: "1"})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	resp, err := c.ListAccounts(ctx, &pb.ListAccountsRequest{})
+	if err != nil {
+		t.Fatalf("ListAccounts() error = %v", err)
+	}
+	if len(resp.Accounts) != numAccounts {
+		t.Fatalf("len(Accounts) = %v, want %v", len(resp.Accounts), numAccounts)
+	}
+
+	total := big.NewInt(0)
+	for _, a := range resp.Accounts {
+		bal, ok := new(big.Int).SetString(a.Balance, 10)
+		if !ok {
+			t.Fatalf("invalid balance %q for %q", a.Balance, a.Name)
+		}
+		total.Add(total, bal)
+	}
+	want := big.NewInt(numAccounts * 1000)
+	if total.Cmp(want) != 0 {
+		t.Errorf("total balance = %s, want %s (money was created or destroyed)", total, want)
+	}
+}
+
+func TestGRPCHistoryStream(t *testing.T) {
+	b := bank.NewBank(bank.NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+	s := New(b)
+	c := dialGRPC(t, s)
+	ctx := context.Background()
+
+	if _, err := c.NewAccount(ctx, &pb.NewAccountRequest{Name: "Pike"}); err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := c.Deposit(ctx, &pb.AmountRequest{Name: "Pike", Amount: "100"}); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+	if _, err := c.Withdraw(ctx, &pb.AmountRequest{Name: "Pike", Amount: "40"}); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+
+	stream, err := c.History(ctx, &pb.HistoryRequest{Name: "Pike"})
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	var entries []*pb.HistoryEntry
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 || entries[0].Balance != "100" || entries[1].Balance != "60" {
+		t.Errorf("entries = %+v, want balances 100, 60", entries)
+	}
+}
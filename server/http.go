@@ -0,0 +1,234 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"bank"
+)
+
+// HTTPHandler returns an http.Handler exposing the bank's operations as
+// a REST/JSON API:
+//
+//	POST   /accounts                 {"name": "..."}          -> account
+//	GET    /accounts                                          -> []account
+//	GET    /accounts/{name}                                   -> account
+//	POST   /accounts/{name}/deposit  {"amount": N}            -> account
+//	POST   /accounts/{name}/withdraw {"amount": N}            -> account
+//	GET    /accounts/{name}/history                           -> streamed []historyEntry
+//	POST   /transfer  {"from": "...", "to": "...", "amount": N} -> {from, to}
+func (s *Server) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/accounts", s.handleAccounts)
+	mux.HandleFunc("/accounts/", s.handleAccount)
+	mux.HandleFunc("/transfer", s.handleTransfer)
+	return mux
+}
+
+// accountResponse is the JSON shape of an account: just enough to
+// answer "who is this" and "what's the balance", since Hist is exposed
+// separately through the history endpoint.
+type accountResponse struct {
+	Name    string   `json:"name"`
+	Balance *big.Int `json:"balance"`
+}
+
+func toAccountResponse(a *bank.Account) accountResponse {
+	return accountResponse{Name: bank.Name(a), Balance: bank.Balance(a)}
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a, err := s.Bank.NewAccount(req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, toAccountResponse(a))
+	case http.MethodGet:
+		names := s.Bank.AccountNames()
+		accounts := make([]accountResponse, 0, len(names))
+		for _, name := range names {
+			a, err := s.Bank.GetAccount(name)
+			if err != nil {
+				continue
+			}
+			accounts = append(accounts, toAccountResponse(a))
+		}
+		writeJSON(w, http.StatusOK, accounts)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	parts := strings.SplitN(rest, "/", 2)
+	name := parts[0]
+	if name == "" {
+		http.Error(w, "account name required", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		a, err := s.Bank.GetAccount(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, toAccountResponse(a))
+		return
+	}
+
+	switch parts[1] {
+	case "deposit":
+		s.handleAmount(w, r, name, s.Bank.Deposit)
+	case "withdraw":
+		s.handleAmount(w, r, name, s.Bank.Withdraw)
+	case "history":
+		s.handleHistory(w, r, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleAmount(w http.ResponseWriter, r *http.Request, name string, op func(*bank.Account, *big.Int) (*big.Int, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a, err := s.Bank.GetAccount(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var req struct {
+		Amount *big.Int `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Amount == nil {
+		http.Error(w, "amount required", http.StatusBadRequest)
+		return
+	}
+	if _, err := op(a, req.Amount); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAccountResponse(a))
+}
+
+// historyEntry mirrors one value produced by bank.History's closure.
+type historyEntry struct {
+	Amount  *big.Int `json:"amount"`
+	Balance *big.Int `json:"balance"`
+}
+
+// handleHistory streams an account's history as a chunked JSON array
+// instead of materializing it, mirroring the closure-based
+// bank.History iterator it's built on.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	a, err := s.Bank.GetAccount(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+
+	next := bank.History(a)
+	enc := json.NewEncoder(w)
+	w.Write([]byte("["))
+	first := true
+	for {
+		amt, bal, more := next()
+		if amt == nil {
+			break
+		}
+		if !first {
+			w.Write([]byte(","))
+		}
+		first = false
+		enc.Encode(historyEntry{Amount: amt, Balance: bal})
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if !more {
+			break
+		}
+	}
+	w.Write([]byte("]"))
+}
+
+func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		From   string   `json:"from"`
+		To     string   `json:"to"`
+		Amount *big.Int `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, err := s.Bank.GetAccount(req.From)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	to, err := s.Bank.GetAccount(req.To)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if req.Amount == nil {
+		http.Error(w, "amount required", http.StatusBadRequest)
+		return
+	}
+	fromBal, toBal, err := s.Bank.Transfer(from, to, req.Amount)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		From accountResponse `json:"from"`
+		To   accountResponse `json:"to"`
+	}{
+		From: accountResponse{Name: req.From, Balance: fromBal},
+		To:   accountResponse{Name: req.To, Balance: toBal},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
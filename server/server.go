@@ -0,0 +1,23 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package server exposes a bank.Bank over two transports, a REST/JSON
+// HTTP API (http.go) and a gRPC service defined by pb/bank.proto
+// (grpc.go). Both transports share the same Server and ultimately call
+// straight into the wrapped *bank.Bank, so they're always consistent
+// with each other and with the bank package's own locking.
+package server
+
+import "bank"
+
+// Server adapts a *bank.Bank to the HTTP and gRPC transports implemented
+// in this package.
+type Server struct {
+	Bank *bank.Bank
+}
+
+// New returns a Server backed by b.
+func New(b *bank.Bank) *Server {
+	return &Server{Bank: b}
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"bank"
+)
+
+func TestServerConcurrentTransfers(t *testing.T) {
+	b := bank.NewBank(bank.NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+	s := New(b)
+	ts := httptest.NewServer(s.HTTPHandler())
+	defer ts.Close()
+
+	const (
+		numAccounts   = 6
+		numGoroutines = 20
+		numRounds     = 50
+		startBal      = 1000
+	)
+
+	names := make([]string, numAccounts)
+	for i := range names {
+		names[i] = fmt.Sprintf("acct-%d", i)
+		mustPost(t, ts.URL+"/accounts", map[string]interface{}{"name": names[i]})
+		mustPost(t, ts.URL+"/accounts/"+names[i]+"/deposit", map[string]interface{}{"amount": startBal})
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func(seed int) {
+			defer wg.Done()
+			for r := 0; r < numRounds; r++ {
+				from := names[(seed+r)%numAccounts]
+				to := names[(seed+r+1)%numAccounts]
+				if from == to {
+					continue
+				}
+				// Ignore insufficient-funds errors: expected under
+				// concurrent load and don't affect conservation.
+				resp, err := http.Post(ts.URL+"/transfer", "application/json",
+					bytes.NewReader(mustMarshal(t, map[string]interface{}{"from": from, "to": to, "amount": 1})))
+				if err == nil {
+					resp.Body.Close()
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	resp, err := http.Get(ts.URL + "/accounts")
+	if err != nil {
+		t.Fatalf("GET /accounts error = %v", err)
+	}
+	defer resp.Body.Close()
+	var accounts []accountResponse
+	if err := json.NewDecoder(resp.Body).Decode(&accounts); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(accounts) != numAccounts {
+		t.Fatalf("len(accounts) = %v, want %v", len(accounts), numAccounts)
+	}
+
+	total := big.NewInt(0)
+	for _, a := range accounts {
+		total.Add(total, a.Balance)
+	}
+	want := big.NewInt(numAccounts * startBal)
+	if total.Cmp(want) != 0 {
+		t.Errorf("total balance = %s, want %s (money was created or destroyed)", total, want)
+	}
+}
+
+func TestServerHistoryStream(t *testing.T) {
+	b := bank.NewBank(bank.NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+	s := New(b)
+	ts := httptest.NewServer(s.HTTPHandler())
+	defer ts.Close()
+
+	mustPost(t, ts.URL+"/accounts", map[string]interface{}{"name": "Pike"})
+	mustPost(t, ts.URL+"/accounts/Pike/deposit", map[string]interface{}{"amount": 100})
+	mustPost(t, ts.URL+"/accounts/Pike/withdraw", map[string]interface{}{"amount": 40})
+
+	resp, err := http.Get(ts.URL + "/accounts/Pike/history")
+	if err != nil {
+		t.Fatalf("GET history error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	var entries []historyEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %v, want 2", len(entries))
+	}
+	if entries[0].Balance.Cmp(big.NewInt(100)) != 0 || entries[1].Balance.Cmp(big.NewInt(60)) != 0 {
+		t.Errorf("entries = %+v, want balances 100, 60", entries)
+	}
+}
+
+func mustPost(t *testing.T, url string, body map[string]interface{}) {
+	t.Helper()
+	resp, err := http.Post(url, "application/json", bytes.NewReader(mustMarshal(t, body)))
+	if err != nil {
+		t.Fatalf("POST %s error = %v", url, err)
+	}
+	resp.Body.Close()
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return data
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"encoding/gob"
+
+	"github.com/pkg/errors"
+)
+
+// Storage is a pluggable persistence backend for a Bank's accounts.
+// Bank serializes its own calls into Save (see Bank.Save), so an
+// implementation never has to handle two Save calls racing each other;
+// it still must be safe to call Save and Load from different
+// goroutines, since Load typically only ever runs once at startup.
+type Storage interface {
+	// Save persists accounts, replacing whatever was stored before.
+	Save(accounts map[string]*Account) error
+	// Load returns the persisted accounts, or an empty map if nothing
+	// has been saved yet.
+	Load() (map[string]*Account, error)
+}
+
+// GobFileStorage persists accounts as a single gob-encoded file. This is
+// the original bank.data format; Load also migrates a file written
+// before Account.Bal became a *big.Int (see migrate.go).
+type GobFileStorage struct {
+	Path string
+}
+
+// NewGobFileStorage returns a GobFileStorage that reads from and writes
+// to the file at path.
+func NewGobFileStorage(path string) *GobFileStorage {
+	return &GobFileStorage{Path: path}
+}
+
+// Save gob-encodes accounts to s.Path.
+func (s *GobFileStorage) Save(accounts map[string]*Account) (err error) {
+	f, err := os.OpenFile(s.Path, os.O_WRONLY, 0666) // Note: octal #
+	if err != nil {
+		f, err = os.Create(s.Path)
+		if err != nil {
+			return errors.Wrap(err, "GobFileStorage.Save: Create failed")
+		}
+	}
+	defer func() {
+		e := f.Close()
+		if e != nil {
+			if err == nil {
+				err = e
+				return
+			}
+			err = errors.Wrap(err, e.Error())
+		}
+	}()
+
+	e := gob.NewEncoder(f)
+	err = e.Encode(accounts)
+	if err != nil {
+		return errors.Wrap(err, "GobFileStorage.Save: Encode failed")
+	}
+	return nil
+}
+
+// Load gob-decodes accounts from s.Path. A missing file is not an
+// error; it yields an empty map.
+func (s *GobFileStorage) Load() (map[string]*Account, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Expected. The file does not exist initially.
+			return map[string]*Account{}, nil
+		}
+		return nil, errors.Wrap(err, "GobFileStorage.Load: Open failed")
+	}
+	defer f.Close() // closing a readonly file needs no error checking
+
+	accounts, err := decodeAccounts(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "GobFileStorage.Load: Decode failed")
+	}
+	return accounts, nil
+}
+
+// JSONFileStorage persists accounts as a single, human-diffable JSON
+// file. *big.Int implements json.Marshaler/Unmarshaler, so Account
+// round-trips through encoding/json without any extra glue code.
+type JSONFileStorage struct {
+	Path string
+}
+
+// NewJSONFileStorage returns a JSONFileStorage that reads from and
+// writes to the file at path.
+func NewJSONFileStorage(path string) *JSONFileStorage {
+	return &JSONFileStorage{Path: path}
+}
+
+// Save JSON-encodes accounts to s.Path.
+func (s *JSONFileStorage) Save(accounts map[string]*Account) error {
+	data, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "JSONFileStorage.Save: Marshal failed")
+	}
+	if err := ioutil.WriteFile(s.Path, data, 0666); err != nil {
+		return errors.Wrap(err, "JSONFileStorage.Save: WriteFile failed")
+	}
+	return nil
+}
+
+// Load JSON-decodes accounts from s.Path. A missing file is not an
+// error; it yields an empty map.
+func (s *JSONFileStorage) Load() (map[string]*Account, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]*Account{}, nil
+		}
+		return nil, errors.Wrap(err, "JSONFileStorage.Load: ReadFile failed")
+	}
+
+	accounts := map[string]*Account{}
+	if err := json.Unmarshal(data, &accounts); err != nil {
+		return nil, errors.Wrap(err, "JSONFileStorage.Load: Unmarshal failed")
+	}
+	return accounts, nil
+}
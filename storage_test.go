@@ -0,0 +1,124 @@
+package bank
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGobFileStorageSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.data")
+	s := NewGobFileStorage(path)
+
+	a := &Account{Name: "Pike", Bal: big64(100)}
+	a.Hist = append(a.Hist, history{big64(100), big64(100)})
+	want := map[string]*Account{"Pike": a}
+
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 1 || got["Pike"].Bal.Cmp(big64(100)) != 0 {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestGobFileStorageLoadMissingFile(t *testing.T) {
+	s := NewGobFileStorage(filepath.Join(t.TempDir(), "does-not-exist.data"))
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Load() = %v, want empty map", got)
+	}
+}
+
+func TestBoltStorageSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.bolt")
+	s, err := NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("NewBoltStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	pike := &Account{Name: "Pike", Bal: big64(100)}
+	pike.Hist = append(pike.Hist, history{big64(100), big64(100)})
+	thompson := &Account{Name: "Thompson", Bal: big64(42)}
+	want := map[string]*Account{"Pike": pike, "Thompson": thompson}
+
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("len(Load()) = %v, want 2", len(got))
+	}
+	if got["Pike"].Bal.Cmp(big64(100)) != 0 || len(got["Pike"].Hist) != 1 {
+		t.Errorf("Load()[%q] = %+v, want balance 100 with 1 history entry", "Pike", got["Pike"])
+	}
+	if got["Thompson"].Bal.Cmp(big64(42)) != 0 {
+		t.Errorf("Load()[%q] = %+v, want balance 42", "Thompson", got["Thompson"])
+	}
+}
+
+func TestBoltStorageSaveRemovesDroppedAccounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.bolt")
+	s, err := NewBoltStorage(path)
+	if err != nil {
+		t.Fatalf("NewBoltStorage() error = %v", err)
+	}
+	defer s.Close()
+
+	pike := &Account{Name: "Pike", Bal: big64(100)}
+	thompson := &Account{Name: "Thompson", Bal: big64(42)}
+	if err := s.Save(map[string]*Account{"Pike": pike, "Thompson": thompson}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A second Save with Thompson missing should replace the whole
+	// stored set, not just add/update Pike.
+	if err := s.Save(map[string]*Account{"Pike": pike}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("len(Load()) = %v, want 1", len(got))
+	}
+	if _, ok := got["Thompson"]; ok {
+		t.Errorf("Load() still has %q, want it removed", "Thompson")
+	}
+}
+
+func TestJSONFileStorageSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.json")
+	s := NewJSONFileStorage(path)
+
+	a := &Account{Name: "Pike", Bal: big64(100)}
+	a.Hist = append(a.Hist, history{big64(100), big64(100)})
+	want := map[string]*Account{"Pike": a}
+
+	if err := s.Save(want); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) != 1 || got["Pike"].Bal.Cmp(big64(100)) != 0 {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Tx is a signed instruction to move funds, modeled after the
+// account/nonce/signature shape used by tendermint and ethereum. It
+// lets Deposit, Withdraw, and Transfer be driven by a signed message
+// instead of a trusted in-process *Account pointer: see
+// SignedDeposit/SignedWithdraw/SignedTransfer. To is unused by Deposit
+// and Withdraw.
+type Tx struct {
+	From      string
+	To        string
+	Amount    *big.Int
+	Sequence  uint64
+	Signature []byte
+}
+
+// SignBytes returns the canonical byte representation of tx that gets
+// signed and verified. It excludes Signature itself.
+func SignBytes(tx *Tx) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%s|%d", tx.From, tx.To, tx.Amount.String(), tx.Sequence))
+}
+
+// PrivAccount pairs an Account with the ed25519 private key that signs
+// transactions on its behalf. Production callers only ever see the
+// public Account; PrivAccount exists for tests and CLI tools that need
+// to create and sign Txs.
+type PrivAccount struct {
+	Account *Account
+	PrivKey ed25519.PrivateKey
+}
+
+// NewPrivAccount generates a fresh ed25519 keypair and creates an
+// account named s on b with that public key attached, ready to submit
+// signed Txs.
+func (b *Bank) NewPrivAccount(s string) (*PrivAccount, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewPrivAccount: GenerateKey failed")
+	}
+	a, err := b.NewAccount(s)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	a.PubKey = pub
+	a.mu.Unlock()
+	return &PrivAccount{Account: a, PrivKey: priv}, nil
+}
+
+// SignTx signs tx with p's private key, filling in its Signature field,
+// and returns tx for chaining.
+func (p *PrivAccount) SignTx(tx *Tx) *Tx {
+	tx.Signature = ed25519.Sign(p.PrivKey, SignBytes(tx))
+	return tx
+}
+
+// verifyLocked checks tx's signature against a's stored public key and
+// enforces a strictly-increasing Sequence to reject replays, bumping
+// a.Sequence as soon as it passes. The caller must already hold a.mu,
+// and must keep holding it through the mutation that applies tx: Sequence
+// is consumed as soon as it passes verification, even if the operation
+// built on top of it later fails (e.g. insufficient funds) — exactly
+// like an Ethereum nonce — which only holds if no other Tx for a can be
+// verified or applied in between, so verification and application must
+// share one critical section.
+func verifyLocked(a *Account, tx *Tx) error {
+	if a.PubKey == nil {
+		return errors.Errorf("verifyLocked: account %q has no public key; use the trusted-mode operations instead", tx.From)
+	}
+	if tx.Amount == nil {
+		return errors.Errorf("verifyLocked: amount must not be nil for account %q", tx.From)
+	}
+	if tx.Amount.Sign() < 0 {
+		return errors.Errorf("verifyLocked: amount must be positive for account %q, but is %s", tx.From, tx.Amount.String())
+	}
+	if !ed25519.Verify(a.PubKey, SignBytes(tx), tx.Signature) {
+		return errors.Errorf("verifyLocked: invalid signature for account %q", tx.From)
+	}
+	if tx.Sequence != a.Sequence+1 {
+		return errors.Errorf("verifyLocked: invalid sequence %d for account %q, want %d", tx.Sequence, tx.From, a.Sequence+1)
+	}
+	a.Sequence = tx.Sequence
+	return nil
+}
+
+// SignedDeposit verifies tx and, if valid, deposits tx.Amount into its
+// From account. Verification and the deposit happen under the same
+// account lock, so a concurrent Tx for the same account can never be
+// applied out of Sequence order between the two.
+func (b *Bank) SignedDeposit(tx *Tx) (*big.Int, error) {
+	a, err := b.GetAccount(tx.From)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := verifyLocked(a, tx); err != nil {
+		return new(big.Int).Set(a.Bal), err
+	}
+	return b.depositLocked(a, tx.Amount)
+}
+
+// SignedWithdraw verifies tx and, if valid, withdraws tx.Amount from its
+// From account. See SignedDeposit for why verification and the withdrawal
+// share a lock.
+func (b *Bank) SignedWithdraw(tx *Tx) (*big.Int, error) {
+	a, err := b.GetAccount(tx.From)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := verifyLocked(a, tx); err != nil {
+		return new(big.Int).Set(a.Bal), err
+	}
+	return b.withdrawLocked(a, tx.Amount)
+}
+
+// SignedTransfer verifies tx and, if valid, transfers tx.Amount from its
+// From account to its To account. See SignedDeposit for why verification
+// and the transfer share a lock.
+func (b *Bank) SignedTransfer(tx *Tx) (*big.Int, *big.Int, error) {
+	from, err := b.GetAccount(tx.From)
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := b.GetAccount(tx.To)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock := lockPair(from, to)
+	defer unlock()
+	if err := verifyLocked(from, tx); err != nil {
+		return new(big.Int).Set(from.Bal), new(big.Int).Set(to.Bal), err
+	}
+	return b.transferLocked(from, to, tx.Amount)
+}
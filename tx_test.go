@@ -0,0 +1,172 @@
+package bank
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSignedDeposit(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	pike, err := b.NewPrivAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+
+	tx := pike.SignTx(&Tx{From: "Pike", Amount: big64(100), Sequence: 1})
+	got, err := b.SignedDeposit(tx)
+	if err != nil {
+		t.Fatalf("SignedDeposit() error = %v", err)
+	}
+	if got.Cmp(big64(100)) != 0 {
+		t.Errorf("SignedDeposit() = %v, want 100", got)
+	}
+}
+
+func TestSignedDepositRejectsReplay(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	pike, err := b.NewPrivAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+
+	tx := pike.SignTx(&Tx{From: "Pike", Amount: big64(100), Sequence: 1})
+	if _, err := b.SignedDeposit(tx); err != nil {
+		t.Fatalf("SignedDeposit() error = %v", err)
+	}
+	if _, err := b.SignedDeposit(tx); err == nil {
+		t.Error("SignedDeposit() replay succeeded, want error")
+	}
+}
+
+func TestSignedDepositRejectsBadSignature(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	if _, err := b.NewPrivAccount("Pike"); err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+	other, err := b.NewPrivAccount("Thompson")
+	if err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+
+	tx := other.SignTx(&Tx{From: "Pike", Amount: big64(100), Sequence: 1})
+	if _, err := b.SignedDeposit(tx); err == nil {
+		t.Error("SignedDeposit() with wrong signer succeeded, want error")
+	}
+}
+
+func TestSignedTransfer(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	pike, err := b.NewPrivAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+	if _, err := b.NewPrivAccount("Thompson"); err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+
+	deposit := pike.SignTx(&Tx{From: "Pike", Amount: big64(100), Sequence: 1})
+	if _, err := b.SignedDeposit(deposit); err != nil {
+		t.Fatalf("SignedDeposit() error = %v", err)
+	}
+
+	transfer := pike.SignTx(&Tx{From: "Pike", To: "Thompson", Amount: big64(40), Sequence: 2})
+	fromBal, toBal, err := b.SignedTransfer(transfer)
+	if err != nil {
+		t.Fatalf("SignedTransfer() error = %v", err)
+	}
+	if fromBal.Cmp(big64(60)) != 0 || toBal.Cmp(big64(40)) != 0 {
+		t.Errorf("SignedTransfer() = %v, %v, want 60, 40", fromBal, toBal)
+	}
+}
+
+func TestDepositStillWorksWithoutPubKey(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	a, err := b.NewAccount("Griesemer")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := b.Deposit(a, big64(50)); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+	if got := Balance(a); got.Cmp(big64(50)) != 0 {
+		t.Errorf("Balance() = %v, want 50", got)
+	}
+}
+
+func TestSignedDepositRequiresPubKey(t *testing.T) {
+	b := NewBank(NewGobFileStorage("bank.data"))
+	if _, err := b.NewAccount("Griesemer"); err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+
+	tx := &Tx{From: "Griesemer", Amount: big64(50), Sequence: 1}
+	if _, err := b.SignedDeposit(tx); err == nil {
+		t.Error("SignedDeposit() on a pubkey-less account succeeded, want error")
+	}
+}
+
+// TestSignedTxsApplyInSequenceOrder submits a deposit at Sequence N and a
+// dependent withdrawal at Sequence N+1 from two goroutines racing each
+// other, round after round. The withdrawal retries until its sequence
+// check passes -- exactly what a well-behaved client does when it knows
+// its predecessor must land first -- and once it does, the withdrawal
+// must never fail for insufficient funds. If verification and mutation
+// ever ran as two separate critical sections, the deposit's verification
+// step alone was enough to advance a.Sequence, so the withdrawal could
+// see a passing sequence check before the deposit's balance update was
+// actually visible, and spuriously fail. With verification and mutation
+// sharing one lock, that can't happen: by the time the withdrawal's
+// sequence check passes, the deposit's balance update is guaranteed
+// visible too.
+func TestSignedTxsApplyInSequenceOrder(t *testing.T) {
+	const (
+		numRounds = 500
+		amount    = 10
+	)
+
+	b := NewBank(NewGobFileStorage("bank.data"))
+	pike, err := b.NewPrivAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewPrivAccount() error = %v", err)
+	}
+
+	for r := 0; r < numRounds; r++ {
+		deposit := pike.SignTx(&Tx{From: "Pike", Amount: big64(amount), Sequence: uint64(2*r + 1)})
+		withdraw := pike.SignTx(&Tx{From: "Pike", Amount: big64(amount), Sequence: uint64(2*r + 2)})
+
+		var wg sync.WaitGroup
+		var depositErr, withdrawErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, depositErr = b.SignedDeposit(deposit)
+		}()
+		go func() {
+			defer wg.Done()
+			// Retry while the withdrawal's own sequence number hasn't
+			// come up yet -- this is the race: it spins until it sees
+			// the deposit has bumped a.Sequence, at which point the
+			// deposit's balance update must be visible too.
+			for {
+				_, err := b.SignedWithdraw(withdraw)
+				if err == nil || !strings.Contains(err.Error(), "invalid sequence") {
+					withdrawErr = err
+					return
+				}
+			}
+		}()
+		wg.Wait()
+
+		if depositErr != nil {
+			t.Fatalf("round %d: SignedDeposit() error = %v", r, depositErr)
+		}
+		if withdrawErr != nil {
+			t.Fatalf("round %d: SignedWithdraw() error = %v", r, withdrawErr)
+		}
+	}
+
+	if got := Balance(pike.Account); got.Cmp(big64(0)) != 0 {
+		t.Errorf("final balance = %v, want 0", got)
+	}
+}
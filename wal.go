@@ -0,0 +1,141 @@
+// Copyright 2017 Christoph Berger. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bank
+
+import (
+	"encoding/gob"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// walOp identifies which Bank operation a walRecord represents.
+type walOp int
+
+const (
+	walNewAccount walOp = iota
+	walDeposit
+	walWithdraw
+	walTransfer
+)
+
+// walRecord is a single write-ahead log entry. Only the fields relevant
+// to Op are populated.
+type walRecord struct {
+	Op     walOp
+	Name   string // NewAccount, Deposit, Withdraw
+	From   string // Transfer
+	To     string // Transfer
+	Amount *big.Int
+}
+
+// WAL is a write-ahead log: every mutating Bank operation appends a
+// walRecord here before its in-memory state changes, so Load can replay
+// it on top of the last snapshot to recover from a crash.
+type WAL struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	enc  *gob.Encoder
+}
+
+// OpenWAL opens (creating if necessary) the WAL file at path, appending
+// to whatever it already contains.
+func OpenWAL(path string) (*WAL, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenWAL: OpenFile failed")
+	}
+	return &WAL{path: path, f: f, enc: gob.NewEncoder(f)}, nil
+}
+
+// append writes r to the log and flushes it to disk before returning,
+// so that a crash right after append returns cannot lose the record.
+func (w *WAL) append(r walRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.enc.Encode(&r); err != nil {
+		return errors.Wrap(err, "WAL.append: Encode failed")
+	}
+	return errors.Wrap(w.f.Sync(), "WAL.append: Sync failed")
+}
+
+// replay reads every record written so far, in order. A trailing
+// incomplete record (from a crash mid-append) is silently dropped.
+func (w *WAL) replay() ([]walRecord, error) {
+	f, err := os.Open(w.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "WAL.replay: Open failed")
+	}
+	defer f.Close()
+
+	var records []walRecord
+	d := gob.NewDecoder(f)
+	for {
+		var r walRecord
+		if err := d.Decode(&r); err != nil {
+			break
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// truncate empties the WAL file, e.g. after Compact has folded its
+// records into a fresh snapshot.
+func (w *WAL) truncate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.f.Truncate(0); err != nil {
+		return errors.Wrap(err, "WAL.truncate: Truncate failed")
+	}
+	if _, err := w.f.Seek(0, 0); err != nil {
+		return errors.Wrap(err, "WAL.truncate: Seek failed")
+	}
+	w.enc = gob.NewEncoder(w.f)
+	return nil
+}
+
+// Close closes the underlying WAL file.
+func (w *WAL) Close() error {
+	return w.f.Close()
+}
+
+// replayWAL applies every record in w on top of accounts, as Load does
+// after reading the last snapshot.
+func replayWAL(accounts map[string]*Account, w *WAL) error {
+	records, err := w.replay()
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		switch r.Op {
+		case walNewAccount:
+			if _, ok := accounts[r.Name]; !ok {
+				accounts[r.Name] = &Account{Name: r.Name, Bal: big.NewInt(0)}
+			}
+		case walDeposit:
+			a := accounts[r.Name]
+			a.Bal = new(big.Int).Add(a.Bal, r.Amount)
+			a.Hist = append(a.Hist, history{new(big.Int).Set(r.Amount), new(big.Int).Set(a.Bal)})
+		case walWithdraw:
+			a := accounts[r.Name]
+			a.Bal = new(big.Int).Sub(a.Bal, r.Amount)
+			a.Hist = append(a.Hist, history{new(big.Int).Neg(r.Amount), new(big.Int).Set(a.Bal)})
+		case walTransfer:
+			from, to := accounts[r.From], accounts[r.To]
+			from.Bal = new(big.Int).Sub(from.Bal, r.Amount)
+			to.Bal = new(big.Int).Add(to.Bal, r.Amount)
+			from.Hist = append(from.Hist, history{new(big.Int).Neg(r.Amount), new(big.Int).Set(from.Bal)})
+			to.Hist = append(to.Hist, history{new(big.Int).Set(r.Amount), new(big.Int).Set(to.Bal)})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package bank
+
+import (
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWALCrashRecovery(t *testing.T) {
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "bank.data")
+	walPath := filepath.Join(dir, "bank.wal")
+
+	storage := NewGobFileStorage(dataPath)
+	wal, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+
+	b := NewBank(storage)
+	b.UseWAL(wal)
+
+	griesemer, err := b.NewAccount("Griesemer")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	pike, err := b.NewAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := b.Deposit(griesemer, big64(100)); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+	if _, _, err := b.Transfer(griesemer, pike, big64(40)); err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	// No Save(): this simulates a crash before the next snapshot, so
+	// Load has to recover purely from the WAL.
+	wal.Close()
+
+	recovered := NewBank(NewGobFileStorage(dataPath))
+	recoveredWAL, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	recovered.UseWAL(recoveredWAL)
+
+	if err := recovered.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	g, err := recovered.GetAccount("Griesemer")
+	if err != nil {
+		t.Fatalf("GetAccount(%q) error = %v", "Griesemer", err)
+	}
+	if Balance(g).Int64() != 60 {
+		t.Errorf("Griesemer balance = %v, want 60", Balance(g))
+	}
+	p, err := recovered.GetAccount("Pike")
+	if err != nil {
+		t.Fatalf("GetAccount(%q) error = %v", "Pike", err)
+	}
+	if Balance(p).Int64() != 40 {
+		t.Errorf("Pike balance = %v, want 40", Balance(p))
+	}
+}
+
+// TestTransferCrashMidWrite simulates a crash while the WAL record for
+// a Transfer is still being written, i.e. before its fsync ever
+// completes. Transfer writes both legs as a single walTransfer record
+// precisely so this can't destroy money: replay must either see the
+// whole transfer or drop it entirely, never apply just the debit.
+func TestTransferCrashMidWrite(t *testing.T) {
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "bank.data")
+	walPath := filepath.Join(dir, "bank.wal")
+
+	wal, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+
+	b := NewBank(NewGobFileStorage(dataPath))
+	b.UseWAL(wal)
+
+	griesemer, err := b.NewAccount("Griesemer")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := b.NewAccount("Pike"); err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := b.Deposit(griesemer, big64(100)); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+
+	// Append a truncated, undecodable walTransfer record directly,
+	// mimicking a crash partway through Transfer's single WAL write
+	// (i.e. before it was ever fully flushed).
+	if _, err := wal.f.Write([]byte{0x01, 0x02, 0x03}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	wal.Close()
+
+	recovered := NewBank(NewGobFileStorage(dataPath))
+	recoveredWAL, err := OpenWAL(walPath)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	recovered.UseWAL(recoveredWAL)
+
+	if err := recovered.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	g, err := recovered.GetAccount("Griesemer")
+	if err != nil {
+		t.Fatalf("GetAccount(%q) error = %v", "Griesemer", err)
+	}
+	p, err := recovered.GetAccount("Pike")
+	if err != nil {
+		t.Fatalf("GetAccount(%q) error = %v", "Pike", err)
+	}
+	total := new(big.Int).Add(Balance(g), Balance(p))
+	if total.Cmp(big64(100)) != 0 {
+		t.Errorf("total balance after crash mid-transfer-write = %v, want 100 (no money created or destroyed)", total)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewGobFileStorage(filepath.Join(dir, "bank.data"))
+	wal, err := OpenWAL(filepath.Join(dir, "bank.wal"))
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+
+	b := NewBank(storage)
+	b.UseWAL(wal)
+
+	if _, err := b.NewAccount("Thompson"); err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+
+	if err := b.Compact(); err != nil {
+		t.Fatalf("Compact() error = %v", err)
+	}
+
+	records, err := wal.replay()
+	if err != nil {
+		t.Fatalf("replay() error = %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("len(records) after Compact() = %v, want 0", len(records))
+	}
+	if got := b.Snapshot(); got != 0 {
+		t.Errorf("Snapshot() after Compact() = %v, want 0 (journal should be reset)", got)
+	}
+}
+
+func TestSnapshotRevert(t *testing.T) {
+	b := NewBank(NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+
+	a, err := b.NewAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	if _, err := b.Deposit(a, big64(100)); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+
+	snapshot := b.Snapshot()
+	if _, err := b.Deposit(a, big64(50)); err != nil {
+		t.Fatalf("Deposit() error = %v", err)
+	}
+	if got := Balance(a); got.Int64() != 150 {
+		t.Fatalf("Balance() = %v, want 150", got)
+	}
+
+	b.RevertToSnapshot(snapshot)
+	if got := Balance(a); got.Int64() != 100 {
+		t.Errorf("Balance() after revert = %v, want 100", got)
+	}
+}
+
+// TestRevertToSnapshotConcurrentSafety checks that RevertToSnapshot
+// doesn't deadlock against concurrent Deposit/Balance calls. It reverts
+// to a snapshot taken right after the account was created, repeatedly,
+// rather than pairing each revert with its own Snapshot(): the journal
+// is one log shared by every account, so a snapshot id only remains
+// valid for reverting as long as no other goroutine has reverted past
+// it (e.g. past the account's own creation) in the meantime.
+func TestRevertToSnapshotConcurrentSafety(t *testing.T) {
+	b := NewBank(NewGobFileStorage(filepath.Join(t.TempDir(), "bank.data")))
+	a, err := b.NewAccount("Pike")
+	if err != nil {
+		t.Fatalf("NewAccount() error = %v", err)
+	}
+	base := b.Snapshot()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			if _, err := b.Deposit(a, big64(1)); err != nil {
+				t.Errorf("Deposit() error = %v", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			b.RevertToSnapshot(base)
+		}()
+		go func() {
+			defer wg.Done()
+			Balance(a)
+		}()
+	}
+	wg.Wait()
+}